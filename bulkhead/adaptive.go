@@ -0,0 +1,312 @@
+package bulkhead
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rafet/goresilience"
+	"github.com/rafet/goresilience/metrics"
+)
+
+const (
+	defaultAdaptiveInitialLimit = 20
+	defaultAdaptiveMinLimit     = 1
+	defaultAdaptiveMaxLimit     = 1000
+	defaultAdaptiveUpdInterval  = 1 * time.Second
+	defaultAdaptiveTolerance    = 0.5
+	defaultAdaptiveMinRTTWindow = 10 * time.Second
+	adaptiveEWMAAlpha           = 0.2
+)
+
+// AdaptiveConfig is the configuration of the adaptive bulkhead.
+type AdaptiveConfig struct {
+	// InitialLimit is the number of concurrent executions allowed when the
+	// bulkhead starts, before the controller has had a chance to adjust it.
+	InitialLimit int
+	// MinLimit and MaxLimit clamp the limit the controller can converge to.
+	MinLimit int
+	MaxLimit int
+	// UpdateInterval is how often the controller recomputes the limit.
+	UpdateInterval time.Duration
+	// Tolerance is the minimum gradient (minRTT/ewmaRTT) accepted as "no
+	// queueing", below it the controller assumes latency inflation and
+	// shrinks the limit.
+	Tolerance float64
+	// MinRTTWindow is the rolling window used to keep track of the minimum
+	// observed RTT, it's reset once it goes stale so the bulkhead can
+	// adapt to a permanently faster (or slower) downstream.
+	MinRTTWindow time.Duration
+	// MetricsRecorder is used by the background controller to report the
+	// current limit and in-flight count, it doesn't depend on a particular
+	// execution's context since the controller runs on its own goroutine.
+	// Defaults to metrics.Dummy.
+	MetricsRecorder metrics.Recorder
+}
+
+func (c *AdaptiveConfig) defaults() {
+	if c.InitialLimit <= 0 {
+		c.InitialLimit = defaultAdaptiveInitialLimit
+	}
+	if c.MinLimit <= 0 {
+		c.MinLimit = defaultAdaptiveMinLimit
+	}
+	if c.MaxLimit <= 0 {
+		c.MaxLimit = defaultAdaptiveMaxLimit
+	}
+	if c.MaxLimit < c.MinLimit {
+		c.MaxLimit = c.MinLimit
+	}
+	if c.InitialLimit > c.MaxLimit {
+		c.InitialLimit = c.MaxLimit
+	}
+	if c.InitialLimit < c.MinLimit {
+		c.InitialLimit = c.MinLimit
+	}
+	if c.UpdateInterval <= 0 {
+		c.UpdateInterval = defaultAdaptiveUpdInterval
+	}
+	if c.Tolerance <= 0 {
+		c.Tolerance = defaultAdaptiveTolerance
+	}
+	if c.MinRTTWindow <= 0 {
+		c.MinRTTWindow = defaultAdaptiveMinRTTWindow
+	}
+	if c.MetricsRecorder == nil {
+		c.MetricsRecorder = metrics.Dummy
+	}
+}
+
+// adaptiveBulkhead implements an AIMD-like concurrency limiter loosely
+// based on the gradient algorithm behind Netflix's concurrency-limits: it
+// grows the limit while there's no sign of queueing (the EWMA of the RTT
+// stays close to the observed minimum RTT) and shrinks it multiplicatively
+// as soon as latency starts inflating.
+type adaptiveBulkhead struct {
+	cfg    AdaptiveConfig
+	runner goresilience.Runner
+
+	tokens      chan struct{}
+	circulating int32 // number of tokens currently in circulation (queued or in use).
+
+	inflight int64
+	waiting  int64
+
+	ewmaRTTNs int64 // atomic, nanoseconds.
+
+	mu          sync.Mutex
+	limit       int32
+	minRTTNs    int64
+	minRTTSince time.Time
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// Closer is implemented by the runners returned by NewAdaptive and
+// NewAdaptiveMiddleware. Close stops the background goroutine that adjusts
+// the concurrency limit, callers that create an adaptive bulkhead for
+// anything shorter than the process lifetime (tests, per-request runners)
+// should Close it once it's no longer needed to avoid leaking the goroutine.
+type Closer interface {
+	Close()
+}
+
+// NewAdaptive returns a new goresilience.Runner that limits the number of
+// concurrent executions using a dynamically resized limit, use 0 values for
+// default settings. The returned Runner also implements Closer, assert to it
+// to stop the background controller goroutine once it's no longer needed.
+func NewAdaptive(cfg AdaptiveConfig) goresilience.Runner {
+	return NewAdaptiveMiddleware(cfg)(nil)
+}
+
+// NewAdaptiveMiddleware returns a middleware equivalent to
+// bulkhead.NewMiddleware but that resizes its concurrency limit over time
+// based on the observed latency instead of using a fixed Workers count. The
+// runners it creates also implement Closer, assert to it to stop the
+// background controller goroutine once it's no longer needed.
+func NewAdaptiveMiddleware(cfg AdaptiveConfig) goresilience.Middleware {
+	cfg.defaults()
+
+	return func(next goresilience.Runner) goresilience.Runner {
+		b := &adaptiveBulkhead{
+			cfg:         cfg,
+			runner:      goresilience.SanitizeRunner(next),
+			tokens:      make(chan struct{}, cfg.MaxLimit),
+			limit:       int32(cfg.InitialLimit),
+			minRTTSince: time.Now(),
+			stop:        make(chan struct{}),
+		}
+		for i := 0; i < cfg.InitialLimit; i++ {
+			b.tokens <- struct{}{}
+		}
+		b.circulating = int32(cfg.InitialLimit)
+
+		go b.controllerLoop()
+
+		return b
+	}
+}
+
+// Close satisfies Closer, it's safe to call more than once.
+func (b *adaptiveBulkhead) Close() {
+	b.closeOnce.Do(func() {
+		close(b.stop)
+	})
+}
+
+func (b *adaptiveBulkhead) controllerLoop() {
+	ticker := time.NewTicker(b.cfg.UpdateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.adjustLimit()
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+func (b *adaptiveBulkhead) Run(ctx context.Context, f goresilience.Func) error {
+	// We measure the RTT from before acquiring a token, so a growing queue
+	// inflates the observed latency and the gradient below reacts to it,
+	// the same way it reacts to the downstream call itself getting slower.
+	start := time.Now()
+
+	atomic.AddInt64(&b.waiting, 1)
+	select {
+	case <-b.tokens:
+		atomic.AddInt64(&b.waiting, -1)
+	case <-ctx.Done():
+		atomic.AddInt64(&b.waiting, -1)
+		return context.Cause(ctx)
+	}
+	atomic.AddInt64(&b.inflight, 1)
+
+	err := b.runner.Run(ctx, f)
+	b.recordLatency(time.Since(start))
+
+	atomic.AddInt64(&b.inflight, -1)
+	b.release()
+
+	return err
+}
+
+// release returns the token to circulation, unless the limit has shrunk
+// since it was acquired, in which case it's dropped instead so circulation
+// gradually converges to the new limit.
+func (b *adaptiveBulkhead) release() {
+	b.mu.Lock()
+	if atomic.LoadInt32(&b.circulating) > b.limit {
+		atomic.AddInt32(&b.circulating, -1)
+		b.mu.Unlock()
+		return
+	}
+	b.mu.Unlock()
+
+	b.tokens <- struct{}{}
+}
+
+func (b *adaptiveBulkhead) recordLatency(d time.Duration) {
+	for {
+		old := atomic.LoadInt64(&b.ewmaRTTNs)
+		var updated int64
+		if old == 0 {
+			updated = int64(d)
+		} else {
+			updated = int64(float64(old)*(1-adaptiveEWMAAlpha) + float64(d)*adaptiveEWMAAlpha)
+		}
+		if atomic.CompareAndSwapInt64(&b.ewmaRTTNs, old, updated) {
+			break
+		}
+	}
+
+	now := time.Now()
+	b.mu.Lock()
+	if now.Sub(b.minRTTSince) > b.cfg.MinRTTWindow {
+		b.minRTTNs = int64(d)
+		b.minRTTSince = now
+	} else if b.minRTTNs == 0 || int64(d) < b.minRTTNs {
+		b.minRTTNs = int64(d)
+	}
+	b.mu.Unlock()
+}
+
+// adjustLimit recomputes the concurrency limit from the gradient between
+// the minimum observed RTT and its current EWMA.
+func (b *adaptiveBulkhead) adjustLimit() {
+	ewma := atomic.LoadInt64(&b.ewmaRTTNs)
+
+	b.mu.Lock()
+	minRTT := b.minRTTNs
+	limit := b.limit
+	b.mu.Unlock()
+
+	if ewma == 0 || minRTT == 0 {
+		// Not enough data yet.
+		return
+	}
+
+	gradient := float64(minRTT) / float64(ewma)
+	if gradient > 1 {
+		gradient = 1
+	}
+
+	var newLimit int32
+	if gradient >= b.cfg.Tolerance {
+		// No significant queueing, grow.
+		newLimit = limit + int32(math.Ceil(math.Sqrt(float64(limit))))
+	} else {
+		// Queueing/latency inflation, shrink multiplicatively.
+		factor := gradient
+		if factor < 0.5 {
+			factor = 0.5
+		}
+		newLimit = int32(math.Ceil(float64(limit) * factor))
+	}
+
+	if newLimit < int32(b.cfg.MinLimit) {
+		newLimit = int32(b.cfg.MinLimit)
+	}
+	if newLimit > int32(b.cfg.MaxLimit) {
+		newLimit = int32(b.cfg.MaxLimit)
+	}
+
+	b.mu.Lock()
+	delta := newLimit - b.limit
+	b.limit = newLimit
+	b.mu.Unlock()
+
+	if delta > 0 {
+		b.growTokens(int(delta))
+	}
+
+	b.cfg.MetricsRecorder.ObserveBulkheadLimit(int(newLimit))
+	b.cfg.MetricsRecorder.ObserveBulkheadInflight(int(atomic.LoadInt64(&b.inflight)))
+}
+
+// State satisfies goresilience.Introspectable.
+func (b *adaptiveBulkhead) State() (breakerState string, inflight int, queueDepth int, errorRate float64) {
+	return "", int(atomic.LoadInt64(&b.inflight)), int(atomic.LoadInt64(&b.waiting)), 0
+}
+
+// Queue satisfies QueueReporter.
+func (b *adaptiveBulkhead) Queue() (inflight int, queueDepth int) {
+	return int(atomic.LoadInt64(&b.inflight)), int(atomic.LoadInt64(&b.waiting))
+}
+
+func (b *adaptiveBulkhead) growTokens(by int) {
+	for i := 0; i < by; i++ {
+		select {
+		case b.tokens <- struct{}{}:
+			atomic.AddInt32(&b.circulating, 1)
+		default:
+			// The token channel is already at MaxLimit capacity.
+			return
+		}
+	}
+}
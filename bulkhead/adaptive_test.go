@@ -0,0 +1,91 @@
+package bulkhead_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rafet/goresilience/bulkhead"
+	"github.com/rafet/goresilience/metrics"
+)
+
+// spyRecorder wraps metrics.Dummy to capture the last observed adaptive
+// bulkhead limit.
+type spyRecorder struct {
+	metrics.Recorder
+	lastLimit int64
+}
+
+func (s *spyRecorder) ObserveBulkheadLimit(limit int) {
+	atomic.StoreInt64(&s.lastLimit, int64(limit))
+}
+
+func TestAdaptiveBulkheadConverges(t *testing.T) {
+	assert := assert.New(t)
+
+	rec := &spyRecorder{Recorder: metrics.Dummy}
+	bk := bulkhead.NewAdaptive(bulkhead.AdaptiveConfig{
+		InitialLimit:    5,
+		MinLimit:        1,
+		MaxLimit:        50,
+		UpdateInterval:  10 * time.Millisecond,
+		MetricsRecorder: rec,
+	})
+	defer bk.(bulkhead.Closer).Close()
+
+	// Drive more concurrent load than the initial limit for a while so the
+	// controller has a chance to grow it, the synthetic work has a stable
+	// latency so there is no reason for the limit to shrink back once it
+	// has grown enough to absorb the load.
+	var wg sync.WaitGroup
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = bk.Run(context.TODO(), func(_ context.Context) error {
+				time.Sleep(5 * time.Millisecond)
+				return nil
+			})
+		}()
+		time.Sleep(200 * time.Microsecond)
+	}
+	wg.Wait()
+
+	gotLimit := int(atomic.LoadInt64(&rec.lastLimit))
+	assert.Greater(gotLimit, 5, "the limit should have grown past the initial one")
+	assert.LessOrEqual(gotLimit, 50, "the limit should never go over MaxLimit")
+}
+
+func TestAdaptiveBulkheadCloseStopsTheController(t *testing.T) {
+	assert := assert.New(t)
+
+	rec := &spyRecorder{Recorder: metrics.Dummy}
+	bk := bulkhead.NewAdaptive(bulkhead.AdaptiveConfig{
+		InitialLimit:    5,
+		MinLimit:        1,
+		MaxLimit:        50,
+		UpdateInterval:  5 * time.Millisecond,
+		MetricsRecorder: rec,
+	})
+	closer := bk.(bulkhead.Closer)
+
+	// Let the controller run at least once so it has reported a limit, it
+	// only does once at least one execution has been recorded.
+	_ = bk.Run(context.TODO(), func(_ context.Context) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	time.Sleep(20 * time.Millisecond)
+	closer.Close()
+	closer.Close() // Close must be safe to call more than once.
+
+	afterClose := atomic.LoadInt64(&rec.lastLimit)
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(afterClose, atomic.LoadInt64(&rec.lastLimit), "the controller shouldn't keep adjusting the limit after Close")
+}
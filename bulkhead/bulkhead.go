@@ -0,0 +1,130 @@
+// Package bulkhead limits the number of concurrent executions of a Func,
+// queueing the rest up to a configurable max wait time.
+package bulkhead
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/rafet/goresilience"
+	"github.com/rafet/goresilience/errors"
+	"github.com/rafet/goresilience/metrics"
+)
+
+const defaultWorkers = 10
+
+// QueueReporter is implemented by the runners returned by New and
+// NewAdaptive, narrowing goresilience.Introspectable.State's inflight and
+// queueDepth fields to the runners that actually track a queue of
+// executions waiting for a free worker. metrics.StateCollector uses it to
+// tell a real bulkhead apart from the other Introspectable runners (circuit
+// breakers, rate limiters...), which always report 0 for both and would
+// otherwise show up as misleading always-zero bulkhead gauges.
+type QueueReporter interface {
+	// Queue returns the same inflight and queueDepth values reported by
+	// State.
+	Queue() (inflight int, queueDepth int)
+}
+
+// Config is the configuration of the bulkhead.
+type Config struct {
+	// Workers is the number of concurrent executions allowed at the same
+	// time.
+	Workers int
+	// MaxWaitTime is the max time an execution will wait for a free worker
+	// before giving up. 0 means it will wait forever.
+	MaxWaitTime time.Duration
+}
+
+func (c *Config) defaults() {
+	if c.Workers <= 0 {
+		c.Workers = defaultWorkers
+	}
+}
+
+type bulkhead struct {
+	cfg    Config
+	tokens chan struct{}
+	runner goresilience.Runner
+
+	inflight int64
+	waiting  int64
+}
+
+// New returns a new goresilience.Runner that limits the number of
+// concurrent executions, use 0 values for default settings.
+func New(cfg Config) goresilience.Runner {
+	return NewMiddleware(cfg)(nil)
+}
+
+// NewMiddleware returns a middleware that limits the number of concurrent
+// executions of the wrapped runner, queueing the rest up to
+// Config.MaxWaitTime.
+func NewMiddleware(cfg Config) goresilience.Middleware {
+	cfg.defaults()
+
+	return func(next goresilience.Runner) goresilience.Runner {
+		return &bulkhead{
+			cfg:    cfg,
+			tokens: make(chan struct{}, cfg.Workers),
+			runner: goresilience.SanitizeRunner(next),
+		}
+	}
+}
+
+func (b *bulkhead) Run(ctx context.Context, f goresilience.Func) error {
+	metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+
+	// waitCtx only bounds the time spent queueing for a free token, it must
+	// never reach b.runner.Run: once a token is acquired we run with the
+	// caller's original ctx, otherwise waitCtx's timer would (eventually)
+	// cancel an execution that is already in flight, long after it stopped
+	// waiting.
+	waitCtx := ctx
+	if b.cfg.MaxWaitTime > 0 {
+		// Cancel the wait with a sentinel cause so we can tell apart a
+		// bulkhead wait timeout from a caller cancellation below, by
+		// calling context.Cause(waitCtx) (waitCtx is ours, so unlike
+		// goresilience.ClassifyError we can rely on it here).
+		var cancel context.CancelCauseFunc
+		waitCtx, cancel = context.WithCancelCause(ctx)
+		timer := time.AfterFunc(b.cfg.MaxWaitTime, func() {
+			cancel(errors.ErrBulkheadWaitTimeout)
+		})
+		defer timer.Stop()
+		defer cancel(nil)
+	}
+
+	atomic.AddInt64(&b.waiting, 1)
+	select {
+	case b.tokens <- struct{}{}:
+		atomic.AddInt64(&b.waiting, -1)
+		atomic.AddInt64(&b.inflight, 1)
+		defer func() {
+			atomic.AddInt64(&b.inflight, -1)
+			<-b.tokens
+		}()
+		return b.runner.Run(ctx, f)
+	case <-waitCtx.Done():
+		atomic.AddInt64(&b.waiting, -1)
+		if context.Cause(waitCtx) != errors.ErrBulkheadWaitTimeout {
+			// ctx itself was cancelled (or its deadline passed) while we
+			// were still queueing, report the real cause instead of
+			// claiming a wait timeout that never happened.
+			return context.Cause(ctx)
+		}
+		metricsRecorder.IncBulkheadWaitTimeout()
+		return errors.ErrBulkheadWaitTimeout
+	}
+}
+
+// State satisfies goresilience.Introspectable.
+func (b *bulkhead) State() (breakerState string, inflight int, queueDepth int, errorRate float64) {
+	return "", int(atomic.LoadInt64(&b.inflight)), int(atomic.LoadInt64(&b.waiting)), 0
+}
+
+// Queue satisfies QueueReporter.
+func (b *bulkhead) Queue() (inflight int, queueDepth int) {
+	return int(atomic.LoadInt64(&b.inflight)), int(atomic.LoadInt64(&b.waiting))
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/rafet/goresilience"
 	"github.com/rafet/goresilience/bulkhead"
+	bulkheaderrors "github.com/rafet/goresilience/errors"
 )
 
 func TestBulkheadTimeout(t *testing.T) {
@@ -83,3 +84,59 @@ func TestBulkheadTimeout(t *testing.T) {
 		})
 	}
 }
+
+// TestBulkheadDoesNotCancelAnInFlightExecution checks that MaxWaitTime only
+// bounds the time spent queueing for a free worker: an execution that
+// already acquired one must be able to run past MaxWaitTime without its
+// context being cancelled.
+func TestBulkheadDoesNotCancelAnInFlightExecution(t *testing.T) {
+	assert := assert.New(t)
+
+	bk := bulkhead.New(bulkhead.Config{
+		Workers:     1,
+		MaxWaitTime: 20 * time.Millisecond,
+	})
+
+	err := bk.Run(context.TODO(), func(ctx context.Context) error {
+		select {
+		case <-time.After(200 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	assert.NoError(err, "MaxWaitTime shouldn't cancel an execution that is already in flight")
+}
+
+// TestBulkheadReportsCallerCancellationWhileQueueing checks that a caller
+// cancellation that happens while an execution is still queueing for a free
+// worker is reported as the real cause, not misreported as a bulkhead wait
+// timeout.
+func TestBulkheadReportsCallerCancellationWhileQueueing(t *testing.T) {
+	assert := assert.New(t)
+
+	// No MaxWaitTime: this bulkhead would wait forever for a free worker.
+	bk := bulkhead.New(bulkhead.Config{Workers: 1})
+
+	// Occupy the only worker so the next Run has to queue.
+	holdCtx, release := context.WithCancel(context.Background())
+	defer release()
+	go bk.Run(holdCtx, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- bk.Run(ctx, func(ctx context.Context) error { return nil })
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	err := <-errc
+	assert.ErrorIs(err, context.Canceled)
+	assert.NotErrorIs(err, bulkheaderrors.ErrBulkheadWaitTimeout)
+}
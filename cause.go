@@ -0,0 +1,57 @@
+package goresilience
+
+import (
+	"context"
+	stderrors "errors"
+
+	"github.com/rafet/goresilience/errors"
+)
+
+// Cause classifies why a Run execution failed, distinguishing a caller
+// initiated cancellation from a cancellation caused by one of our own
+// middlewares (timeout, bulkhead...).
+type Cause int
+
+const (
+	// CauseUnknown means the error wasn't caused by a context cancellation
+	// we can classify, it's a regular error returned by the Func itself.
+	CauseUnknown Cause = iota
+	// CauseUserCancelled means the context was cancelled by the caller
+	// (outside of this library), for example by a cancelled HTTP request.
+	CauseUserCancelled
+	// CauseTimeout means the context was cancelled by timeout.NewMiddleware.
+	CauseTimeout
+	// CauseBulkheadWaitTimeout means the context was cancelled because an
+	// execution waited too long for a free worker on bulkhead.NewMiddleware.
+	CauseBulkheadWaitTimeout
+)
+
+// ClassifyError classifies a non-nil error returned by a Runner, so
+// fallbacks and retries can decide what to do based on why the execution
+// really failed instead of just comparing sentinel errors.
+//
+// Classification is done on err itself rather than on context.Cause(ctx):
+// timeout and bulkhead cancel a context they derive internally for their
+// own wrapped runner, so by the time err reaches a middleware further up
+// the chain (retry wrapping timeout, fallback wrapping bulkhead...) ctx is
+// still the caller's original, uncancelled context and never carries that
+// cause. Each of them instead returns (or propagates) its own sentinel
+// error, which survives up the chain regardless of how deep it was
+// produced, so that's what's checked here. ctx is only used to recognize
+// the caller's own cancellation, by comparing err against ctx.Err().
+func ClassifyError(ctx context.Context, err error) Cause {
+	if err == nil {
+		return CauseUnknown
+	}
+
+	switch {
+	case stderrors.Is(err, errors.ErrTimeout):
+		return CauseTimeout
+	case stderrors.Is(err, errors.ErrBulkheadWaitTimeout):
+		return CauseBulkheadWaitTimeout
+	case ctx.Err() != nil && stderrors.Is(err, ctx.Err()):
+		return CauseUserCancelled
+	default:
+		return CauseUnknown
+	}
+}
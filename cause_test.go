@@ -0,0 +1,53 @@
+package goresilience_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rafet/goresilience"
+	"github.com/rafet/goresilience/bulkhead"
+	"github.com/rafet/goresilience/errors"
+	"github.com/rafet/goresilience/timeout"
+)
+
+// TestClassifyErrorSeesThroughNestedMiddlewares checks that ClassifyError
+// can classify a timeout/bulkhead cause even when ctx is a middleware's own
+// ctx further up the chain, not the child ctx timeout/bulkhead derive
+// internally (which the caller never holds).
+func TestClassifyErrorSeesThroughNestedMiddlewares(t *testing.T) {
+	assert := assert.New(t)
+
+	runner := goresilience.RunnerChain(
+		timeout.NewMiddleware(timeout.Config{Timeout: time.Nanosecond}),
+	)
+
+	ctx := context.Background()
+	err := runner.Run(ctx, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert.Equal(goresilience.CauseTimeout, goresilience.ClassifyError(ctx, err))
+}
+
+func TestClassifyErrorUserCancellation(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	bk := bulkhead.New(bulkhead.Config{Workers: 1})
+	err := bk.Run(ctx, func(ctx context.Context) error { return ctx.Err() })
+
+	assert.Equal(goresilience.CauseUserCancelled, goresilience.ClassifyError(ctx, err))
+}
+
+func TestClassifyErrorUnknown(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(goresilience.CauseUnknown, goresilience.ClassifyError(context.Background(), nil))
+	assert.Equal(goresilience.CauseUnknown, goresilience.ClassifyError(context.Background(), errors.ErrRateLimited))
+}
@@ -99,11 +99,20 @@ func (f *failureInjector) Run(ctx context.Context, fn goresilience.Func) (err er
 	// We don't mind to lock for reading if it's stale data, eventually we will
 	// get the correct values from the injector.
 
-	// Inject latency attack.
+	// Inject latency attack. We wait on the context too (instead of a plain
+	// time.Sleep) so a caller cancellation during the injected latency is
+	// reported with its real cause through context.Cause instead of being
+	// masked until the full latency has passed.
 	lat := f.cfg.Injector.latency
 	if lat > 0 {
 		metricsRecorder.IncChaosInjectedFailure(kindLatency)
-		time.Sleep(lat)
+		timer := time.NewTimer(lat)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return context.Cause(ctx)
+		}
 	}
 
 	// Inject error attack.
@@ -118,3 +127,17 @@ func (f *failureInjector) Run(ctx context.Context, fn goresilience.Func) (err er
 
 	return f.runner.Run(ctx, fn)
 }
+
+// State satisfies goresilience.Introspectable, reporting the error rate of
+// all the executions seen so far, injected failures and errors returned by
+// the wrapped runner alike.
+func (f *failureInjector) State() (breakerState string, inflight int, queueDepth int, errorRate float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var rate float64
+	if f.total > 0 {
+		rate = float64(f.errs) / float64(f.total)
+	}
+	return "", 0, 0, rate
+}
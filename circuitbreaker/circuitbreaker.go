@@ -0,0 +1,230 @@
+// Package circuitbreaker stops calling a failing runner for a while once it
+// has been failing too much, giving the downstream system time to recover.
+package circuitbreaker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rafet/goresilience"
+	"github.com/rafet/goresilience/errors"
+	"github.com/rafet/goresilience/internal/rollingwindow"
+)
+
+type state int
+
+const (
+	closed state = iota
+	open
+	halfOpen
+)
+
+func (s state) String() string {
+	switch s {
+	case open:
+		return "open"
+	case halfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+const (
+	defaultErrorPercentThresholdToOpen        = 50
+	defaultMinimumRequestToOpen               = 20
+	defaultSuccessfulRequiredOnHalfOpen       = 1
+	defaultWaitDurationInOpenState            = 5 * time.Second
+	defaultMetricsSlidingWindowBucketQuantity = 10
+	defaultMetricsBucketDuration              = 1 * time.Second
+)
+
+// Config is the configuration of the circuit breaker.
+type Config struct {
+	// ErrorPercentThresholdToOpen is the error percent (0-100) over the
+	// sliding window that will trip the circuit open.
+	ErrorPercentThresholdToOpen int
+	// MinimumRequestToOpen is the minimum number of requests in the sliding
+	// window required before the error percent is evaluated, this avoids
+	// tripping the circuit on a handful of unlucky requests.
+	MinimumRequestToOpen int
+	// SuccessfulRequiredOnHalfOpen is the number of consecutive successful
+	// requests required while half open to close the circuit again.
+	SuccessfulRequiredOnHalfOpen int
+	// WaitDurationInOpenState is how long the circuit stays open before
+	// moving to half open and letting a request through as a probe.
+	WaitDurationInOpenState time.Duration
+	// MetricsSlidingWindowBucketQuantity is the number of buckets the
+	// sliding window is split into.
+	MetricsSlidingWindowBucketQuantity int
+	// MetricsBucketDuration is the duration covered by every bucket, the
+	// total window covered is MetricsSlidingWindowBucketQuantity *
+	// MetricsBucketDuration.
+	MetricsBucketDuration time.Duration
+}
+
+func (c *Config) defaults() {
+	if c.ErrorPercentThresholdToOpen <= 0 {
+		c.ErrorPercentThresholdToOpen = defaultErrorPercentThresholdToOpen
+	}
+	if c.MinimumRequestToOpen <= 0 {
+		c.MinimumRequestToOpen = defaultMinimumRequestToOpen
+	}
+	if c.SuccessfulRequiredOnHalfOpen <= 0 {
+		c.SuccessfulRequiredOnHalfOpen = defaultSuccessfulRequiredOnHalfOpen
+	}
+	if c.WaitDurationInOpenState <= 0 {
+		c.WaitDurationInOpenState = defaultWaitDurationInOpenState
+	}
+	if c.MetricsSlidingWindowBucketQuantity <= 0 {
+		c.MetricsSlidingWindowBucketQuantity = defaultMetricsSlidingWindowBucketQuantity
+	}
+	if c.MetricsBucketDuration <= 0 {
+		c.MetricsBucketDuration = defaultMetricsBucketDuration
+	}
+}
+
+// bucket holds the total and failed request count of a single window slot.
+type bucket struct {
+	total int
+	errs  int
+}
+
+type circuitBreaker struct {
+	cfg    Config
+	runner goresilience.Runner
+
+	mu                sync.Mutex
+	state             state
+	cursor            *rollingwindow.Cursor
+	buckets           []bucket
+	openedAt          time.Time
+	halfOpenSuccesses int
+}
+
+// New returns a new goresilience.Runner that opens the circuit (rejecting
+// executions with errors.ErrCircuitOpen) once the configured error percent
+// has been reached, use 0 values for default settings.
+func New(cfg Config) goresilience.Runner {
+	return NewMiddleware(cfg)(nil)
+}
+
+// NewMiddleware returns a middleware implementing the circuit breaker
+// pattern around the wrapped runner.
+func NewMiddleware(cfg Config) goresilience.Middleware {
+	cfg.defaults()
+
+	return func(next goresilience.Runner) goresilience.Runner {
+		return &circuitBreaker{
+			cfg:     cfg,
+			runner:  goresilience.SanitizeRunner(next),
+			cursor:  rollingwindow.NewCursor(cfg.MetricsSlidingWindowBucketQuantity, cfg.MetricsBucketDuration, time.Now()),
+			buckets: make([]bucket, cfg.MetricsSlidingWindowBucketQuantity),
+		}
+	}
+}
+
+func (cb *circuitBreaker) Run(ctx context.Context, f goresilience.Func) error {
+	if !cb.allow() {
+		return errors.ErrCircuitOpen
+	}
+
+	err := cb.runner.Run(ctx, f)
+	cb.recordResult(err)
+	return err
+}
+
+// allow decides if a request can go through, moving the circuit from open
+// to half open once WaitDurationInOpenState has passed.
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state != open {
+		return true
+	}
+
+	if time.Since(cb.openedAt) < cb.cfg.WaitDurationInOpenState {
+		return false
+	}
+
+	cb.state = halfOpen
+	cb.halfOpenSuccesses = 0
+	return true
+}
+
+func (cb *circuitBreaker) recordResult(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == halfOpen {
+		if err != nil {
+			cb.trip()
+			return
+		}
+		cb.halfOpenSuccesses++
+		if cb.halfOpenSuccesses >= cb.cfg.SuccessfulRequiredOnHalfOpen {
+			cb.state = closed
+			cb.resetWindow(time.Now())
+		}
+		return
+	}
+
+	cb.advance(time.Now())
+	pos := cb.cursor.Pos()
+	cb.buckets[pos].total++
+	if err != nil {
+		cb.buckets[pos].errs++
+	}
+
+	total, errs := cb.windowTotals()
+	if total < cb.cfg.MinimumRequestToOpen {
+		return
+	}
+	if errPercent := (errs * 100) / total; errPercent >= cb.cfg.ErrorPercentThresholdToOpen {
+		cb.trip()
+	}
+}
+
+func (cb *circuitBreaker) trip() {
+	cb.state = open
+	cb.openedAt = time.Now()
+}
+
+func (cb *circuitBreaker) resetWindow(now time.Time) {
+	for i := range cb.buckets {
+		cb.buckets[i] = bucket{}
+	}
+	cb.cursor.Reset(now)
+}
+
+// advance expires the sub-buckets whose time window has already passed.
+func (cb *circuitBreaker) advance(now time.Time) {
+	for _, idx := range cb.cursor.Advance(now) {
+		cb.buckets[idx] = bucket{}
+	}
+}
+
+func (cb *circuitBreaker) windowTotals() (total, errs int) {
+	for _, b := range cb.buckets {
+		total += b.total
+		errs += b.errs
+	}
+	return total, errs
+}
+
+// State satisfies goresilience.Introspectable, reporting the current
+// breaker state and the error rate over the sliding window.
+func (cb *circuitBreaker) State() (breakerState string, inflight int, queueDepth int, errorRate float64) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	total, errs := cb.windowTotals()
+	var rate float64
+	if total > 0 {
+		rate = float64(errs) / float64(total)
+	}
+
+	return cb.state.String(), 0, 0, rate
+}
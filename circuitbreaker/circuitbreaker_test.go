@@ -0,0 +1,45 @@
+package circuitbreaker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rafet/goresilience"
+	"github.com/rafet/goresilience/circuitbreaker"
+	goresilienceerrors "github.com/rafet/goresilience/errors"
+)
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	assert := assert.New(t)
+
+	runner := circuitbreaker.New(circuitbreaker.Config{
+		ErrorPercentThresholdToOpen:        50,
+		MinimumRequestToOpen:               4,
+		SuccessfulRequiredOnHalfOpen:       1,
+		WaitDurationInOpenState:            10 * time.Millisecond,
+		MetricsSlidingWindowBucketQuantity: 10,
+		MetricsBucketDuration:              time.Second,
+	})
+
+	failing := func(ctx context.Context) error { return errors.New("boom") }
+	for i := 0; i < 4; i++ {
+		_ = runner.Run(context.TODO(), failing)
+	}
+
+	err := runner.Run(context.TODO(), func(ctx context.Context) error { return nil })
+	assert.ErrorIs(err, goresilienceerrors.ErrCircuitOpen)
+
+	time.Sleep(15 * time.Millisecond)
+
+	err = runner.Run(context.TODO(), func(ctx context.Context) error { return nil })
+	assert.NoError(err, "a probe request should be let through once half open")
+
+	err = runner.Run(context.TODO(), func(ctx context.Context) error { return nil })
+	assert.NoError(err, "the circuit should be closed again after a successful probe")
+}
+
+var _ goresilience.Runner = circuitbreaker.New(circuitbreaker.Config{})
@@ -0,0 +1,36 @@
+// Package errors has all the sentinel errors returned by the different
+// goresilience runners so callers can check them with `errors.Is` or simple
+// equality checks (for example to decide a fallback result).
+package errors
+
+import "errors"
+
+var (
+	// ErrTimeout will be returned when a timeout runner cuts the execution
+	// of a Func because it took too long.
+	ErrTimeout = errors.New("timeout while executing")
+	// ErrCircuitOpen will be returned when the circuit breaker runner is in
+	// open state and doesn't let the Func execute.
+	ErrCircuitOpen = errors.New("circuit is open")
+	// ErrFailureInjected will be returned when the chaos runner injects a
+	// failure instead of calling the wrapped runner.
+	ErrFailureInjected = errors.New("failure injected")
+	// ErrRetriesExhausted will be returned when the retry runner has used
+	// all the configured retries and the Func keeps failing.
+	ErrRetriesExhausted = errors.New("retries exhausted")
+	// ErrBulkheadQueueFull signals a full bulkhead queue. No runner of this
+	// module returns it: the bulkhead runners don't bound their queue, they
+	// only bound the wait time (see ErrBulkheadWaitTimeout).
+	ErrBulkheadQueueFull = errors.New("bulkhead queue is full")
+	// ErrBulkheadWaitTimeout will be returned when the bulkhead runner has
+	// been waiting for a free worker for more than the configured max wait
+	// time.
+	ErrBulkheadWaitTimeout = errors.New("bulkhead wait timeout")
+	// ErrRateLimited will be returned when the rate limit runner rejects the
+	// execution because the configured limit has been reached.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrHedgeSuperseded is used as the cancellation cause of the attempts
+	// the hedge runner leaves running after a faster attempt has already
+	// won.
+	ErrHedgeSuperseded = errors.New("hedge attempt superseded by a faster one")
+)
@@ -0,0 +1,117 @@
+// Package fallback replaces the ad-hoc "if err != nil { result = ... }"
+// pattern with a first-class goresilience.Middleware, so the degraded path
+// is part of the runner chain instead of being handled by the caller after
+// Run returns.
+package fallback
+
+import (
+	"context"
+
+	"github.com/rafet/goresilience"
+	"github.com/rafet/goresilience/errors"
+	"github.com/rafet/goresilience/metrics"
+)
+
+// Config is the configuration of the fallback runner.
+type Config struct {
+	// Fallback is the Func that will be executed when the wrapped runner
+	// fails and ShouldFallback returns true for that error.
+	Fallback goresilience.Func
+	// ShouldFallback decides if the Fallback should be executed for the
+	// received error. Defaults to falling back on any non-nil error that
+	// isn't a user initiated cancellation.
+	ShouldFallback func(err error) bool
+}
+
+func (c *Config) defaults() {
+	if c.ShouldFallback == nil {
+		c.ShouldFallback = func(err error) bool { return err != nil }
+	}
+	if c.Fallback == nil {
+		c.Fallback = func(ctx context.Context) error { return nil }
+	}
+}
+
+type fallbacker struct {
+	cfg    Config
+	runner goresilience.Runner
+}
+
+// New returns a new goresilience.Runner that falls back to Config.Fallback
+// when the execution fails.
+func New(cfg Config) goresilience.Runner {
+	return NewMiddleware(cfg)(nil)
+}
+
+// NewMiddleware returns a middleware that executes Config.Fallback when the
+// wrapped runner fails and Config.ShouldFallback agrees, for example when
+// the primary execution returns errors.ErrCircuitOpen, errors.ErrTimeout or
+// errors.ErrFailureInjected. Fallbacks never run for a user initiated
+// context cancellation (see goresilience.ClassifyError), the caller isn't
+// waiting for a result anymore.
+func NewMiddleware(cfg Config) goresilience.Middleware {
+	cfg.defaults()
+
+	return func(next goresilience.Runner) goresilience.Runner {
+		return &fallbacker{
+			cfg:    cfg,
+			runner: goresilience.SanitizeRunner(next),
+		}
+	}
+}
+
+func (fb *fallbacker) Run(ctx context.Context, f goresilience.Func) error {
+	metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+
+	err := fb.runner.Run(ctx, f)
+	if err == nil {
+		return nil
+	}
+
+	if goresilience.ClassifyError(ctx, err) == goresilience.CauseUserCancelled {
+		return err
+	}
+
+	if !fb.cfg.ShouldFallback(err) {
+		return err
+	}
+
+	metricsRecorder.IncFallback(reasonFor(err))
+	return fb.cfg.Fallback(ctx)
+}
+
+// reasonFor turns the failing error into a short, low cardinality reason
+// used to label the IncFallback metric.
+func reasonFor(err error) string {
+	switch err {
+	case errors.ErrCircuitOpen:
+		return "circuit_open"
+	case errors.ErrTimeout:
+		return "timeout"
+	case errors.ErrFailureInjected:
+		return "failure_injected"
+	case errors.ErrBulkheadWaitTimeout:
+		return "bulkhead_wait_timeout"
+	case errors.ErrRateLimited:
+		return "rate_limited"
+	default:
+		return "error"
+	}
+}
+
+// Chain returns a goresilience.Func that tries every received fallback in
+// order, returning as soon as one of them succeeds, or the error of the
+// last one if they all fail. Useful to compose several degraded paths, for
+// example a cache lookup followed by a default value.
+func Chain(fallbacks ...goresilience.Func) goresilience.Func {
+	return func(ctx context.Context) error {
+		var err error
+		for _, fb := range fallbacks {
+			err = fb(ctx)
+			if err == nil {
+				return nil
+			}
+		}
+		return err
+	}
+}
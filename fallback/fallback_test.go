@@ -0,0 +1,75 @@
+package fallback_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rafet/goresilience"
+	"github.com/rafet/goresilience/fallback"
+)
+
+func TestFallbackRunsOnError(t *testing.T) {
+	assert := assert.New(t)
+
+	runner := fallback.New(fallback.Config{
+		Fallback: func(ctx context.Context) error { return nil },
+	})
+
+	err := runner.Run(context.TODO(), func(ctx context.Context) error {
+		return errors.New("boom")
+	})
+
+	assert.NoError(err)
+}
+
+func TestFallbackDoesNotRunOnUserCancellation(t *testing.T) {
+	assert := assert.New(t)
+
+	fallbackCalled := false
+	runner := fallback.New(fallback.Config{
+		Fallback: func(ctx context.Context) error {
+			fallbackCalled = true
+			return nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := runner.Run(ctx, func(ctx context.Context) error {
+		return ctx.Err()
+	})
+
+	assert.Error(err)
+	assert.False(fallbackCalled, "fallback shouldn't run for a user cancelled context")
+}
+
+func TestChainTriesUntilOneSucceeds(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := []string{}
+	fn := fallback.Chain(
+		func(ctx context.Context) error {
+			calls = append(calls, "first")
+			return errors.New("first failed")
+		},
+		func(ctx context.Context) error {
+			calls = append(calls, "second")
+			return nil
+		},
+		func(ctx context.Context) error {
+			calls = append(calls, "third")
+			return nil
+		},
+	)
+
+	err := fn(context.TODO())
+
+	assert.NoError(err)
+	assert.Equal([]string{"first", "second"}, calls)
+}
+
+var _ goresilience.Runner = fallback.New(fallback.Config{})
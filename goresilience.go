@@ -0,0 +1,56 @@
+// Package goresilience provides the building blocks to create resilient
+// execution units. A `Runner` is the core abstraction, it knows how to run a
+// `Func` applying some kind of resilience logic (circuit breaking, retrying,
+// timing out...). `Runner`s can be combined using `Middleware`s and
+// `RunnerChain` to create complex resilient executions by combining simple
+// pieces.
+package goresilience
+
+import "context"
+
+// Func is the way that the user can execute the logic that wants to be
+// executed in a safe way using a Runner.
+type Func func(ctx context.Context) error
+
+// Runner knows how to execute a Func applying some kind of resilience logic
+// to that execution (for example circuit breaking, bulkheads, retries...).
+type Runner interface {
+	// Run will run the received func wrapping it with the required logic.
+	Run(ctx context.Context, f Func) error
+}
+
+// RunnerFunc is a helper to create Runners from functions, in the same way
+// http.HandlerFunc lets a regular function satisfy http.Handler.
+type RunnerFunc func(ctx context.Context, f Func) error
+
+// Run satisfies Runner interface.
+func (r RunnerFunc) Run(ctx context.Context, f Func) error { return r(ctx, f) }
+
+// Middleware knows how to wrap a Runner and return a new decorated one. All
+// the resilience packages (timeout, bulkhead, retry...) expose a
+// `NewMiddleware` constructor so the runners they create can be chained
+// using RunnerChain.
+type Middleware func(next Runner) Runner
+
+// SanitizeRunner will return a dummy runner (one that just calls the Func)
+// when the received Runner is nil, this way middlewares don't need to check
+// for nil runners before wrapping them.
+func SanitizeRunner(r Runner) Runner {
+	if r == nil {
+		return RunnerFunc(func(ctx context.Context, f Func) error {
+			return f(ctx)
+		})
+	}
+	return r
+}
+
+// RunnerChain will create a Runner by chaining all the received middlewares
+// in order, the first middleware received will be the outer one and the
+// last one the inner one (closer to the final Func execution).
+func RunnerChain(mdlws ...Middleware) Runner {
+	var r Runner
+	for i := len(mdlws) - 1; i >= 0; i-- {
+		r = mdlws[i](r)
+	}
+	return SanitizeRunner(r)
+}
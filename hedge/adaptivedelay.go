@@ -0,0 +1,81 @@
+package hedge
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rafet/goresilience/internal/rollingwindow"
+)
+
+// latencyWindow keeps a rolling window of observed latencies split into
+// buckets, storing raw samples instead of counters so we can compute a
+// percentile out of them.
+type latencyWindow struct {
+	mu      sync.Mutex
+	cursor  *rollingwindow.Cursor
+	buckets [][]time.Duration
+}
+
+func newLatencyWindow(window time.Duration, buckets int) *latencyWindow {
+	bucketDur := window / time.Duration(buckets)
+
+	return &latencyWindow{
+		cursor:  rollingwindow.NewCursor(buckets, bucketDur, time.Now()),
+		buckets: make([][]time.Duration, buckets),
+	}
+}
+
+func (w *latencyWindow) observe(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.advance(time.Now())
+	pos := w.cursor.Pos()
+	w.buckets[pos] = append(w.buckets[pos], d)
+}
+
+// advance expires the sub-buckets whose time window has already passed.
+func (w *latencyWindow) advance(now time.Time) {
+	for _, idx := range w.cursor.Advance(now) {
+		w.buckets[idx] = w.buckets[idx][:0]
+	}
+}
+
+func (w *latencyWindow) percentile(p float64) (time.Duration, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.advance(time.Now())
+
+	var all []time.Duration
+	for _, b := range w.buckets {
+		all = append(all, b...)
+	}
+	if len(all) == 0 {
+		return 0, false
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	idx := int(float64(len(all)-1) * p)
+	return all[idx], true
+}
+
+// NewAdaptiveDelayFn returns a Config.DelayFn that computes the hedge delay
+// from the p95 latency observed over a rolling window of the given duration
+// split into buckets sub-buckets, falling back to fallbackDelay while the
+// window holds no sample at all. The returned observe func must be called
+// by the caller with the latency of every completed (non-hedged) execution
+// to feed the window, nothing in this package calls it.
+func NewAdaptiveDelayFn(window time.Duration, buckets int, fallbackDelay time.Duration) (delayFn func(attempt int) time.Duration, observe func(time.Duration)) {
+	w := newLatencyWindow(window, buckets)
+
+	delayFn = func(attempt int) time.Duration {
+		if p95, ok := w.percentile(0.95); ok {
+			return p95
+		}
+		return fallbackDelay
+	}
+
+	return delayFn, w.observe
+}
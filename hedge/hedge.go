@@ -0,0 +1,155 @@
+// Package hedge reduces tail latency by running speculative duplicate
+// attempts of a Func and using the result of whichever finishes first.
+//
+// Func must be idempotent, since hedge may run it more than once
+// concurrently, embedding Idempotent in the type backing it is a way to
+// self-document that requirement.
+package hedge
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rafet/goresilience"
+	"github.com/rafet/goresilience/errors"
+	"github.com/rafet/goresilience/metrics"
+)
+
+const (
+	defaultDelay       = 50 * time.Millisecond
+	defaultMaxAttempts = 2
+)
+
+// Config is the configuration of the hedge runner.
+type Config struct {
+	// Delay is how long the runner waits after launching an attempt before
+	// launching the next one, used when DelayFn is not set.
+	Delay time.Duration
+	// MaxAttempts is the max number of concurrent attempts that will be
+	// launched (the first one included).
+	MaxAttempts int
+	// DelayFn, when set, overrides Delay to compute the wait before
+	// launching attempt N (1-indexed, the first hedge), this allows
+	// exponential backoff between hedges or an adaptive delay based on
+	// observed latency, see NewAdaptiveDelayFn.
+	DelayFn func(attempt int) time.Duration
+}
+
+func (c *Config) defaults() {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaultMaxAttempts
+	}
+	if c.Delay <= 0 && c.DelayFn == nil {
+		c.Delay = defaultDelay
+	}
+}
+
+func (c *Config) delayFor(attempt int) time.Duration {
+	if c.DelayFn != nil {
+		return c.DelayFn(attempt)
+	}
+	return c.Delay
+}
+
+type hedger struct {
+	cfg    Config
+	runner goresilience.Runner
+}
+
+// New returns a new goresilience.Runner that hedges the execution of a
+// Func, use 0 values for default settings.
+func New(cfg Config) goresilience.Runner {
+	return NewMiddleware(cfg)(nil)
+}
+
+// NewMiddleware returns a middleware that runs the wrapped runner
+// speculatively more than once to reduce tail latency, returning the first
+// non-error result and cancelling (with cause errors.ErrHedgeSuperseded) the
+// attempts still running.
+func NewMiddleware(cfg Config) goresilience.Middleware {
+	cfg.defaults()
+
+	return func(next goresilience.Runner) goresilience.Runner {
+		return &hedger{
+			cfg:    cfg,
+			runner: goresilience.SanitizeRunner(next),
+		}
+	}
+}
+
+type attemptResult struct {
+	attempt int
+	err     error
+}
+
+func (h *hedger) Run(ctx context.Context, f goresilience.Func) error {
+	metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	results := make(chan attemptResult, h.cfg.MaxAttempts)
+	var wg sync.WaitGroup
+
+	launched := 0
+	launch := func(attempt int) {
+		launched++
+		metricsRecorder.IncHedgeLaunched()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := h.runner.Run(ctx, f)
+			select {
+			case results <- attemptResult{attempt: attempt, err: err}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+	// We wait for every launched goroutine to either send its result or
+	// observe the cancellation before returning, so we never leak one
+	// holding a reference to the outer ctx.
+	defer wg.Wait()
+
+	launch(0)
+
+	received := 0
+	nextAttempt := 1
+	var lastErr error
+
+	for {
+		var timerC <-chan time.Time
+		var timer *time.Timer
+		if nextAttempt < h.cfg.MaxAttempts {
+			timer = time.NewTimer(h.cfg.delayFor(nextAttempt))
+			timerC = timer.C
+		}
+
+		select {
+		case res := <-results:
+			if timer != nil {
+				timer.Stop()
+			}
+			received++
+			if res.err == nil {
+				metricsRecorder.IncHedgeWon(res.attempt)
+				cancel(errors.ErrHedgeSuperseded)
+				return nil
+			}
+			lastErr = res.err
+			if received == launched && nextAttempt >= h.cfg.MaxAttempts {
+				return lastErr
+			}
+
+		case <-timerC:
+			launch(nextAttempt)
+			nextAttempt++
+
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return context.Cause(ctx)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package hedge_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rafet/goresilience/hedge"
+)
+
+func TestHedgeReturnsFirstSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	runner := hedge.New(hedge.Config{
+		Delay:       5 * time.Millisecond,
+		MaxAttempts: 3,
+	})
+
+	err := runner.Run(context.TODO(), func(ctx context.Context) error {
+		n := atomic.AddInt32(&calls, 1)
+		// Only the first attempt answers quickly, the rest would hang if
+		// they weren't cancelled.
+		if n == 1 {
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert.NoError(err)
+	assert.GreaterOrEqual(atomic.LoadInt32(&calls), int32(2))
+}
+
+func TestHedgeReturnsLastErrorWhenAllFail(t *testing.T) {
+	assert := assert.New(t)
+
+	wantErr := errors.New("always fails")
+	runner := hedge.New(hedge.Config{
+		Delay:       1 * time.Millisecond,
+		MaxAttempts: 2,
+	})
+
+	err := runner.Run(context.TODO(), func(ctx context.Context) error {
+		return wantErr
+	})
+
+	assert.Equal(wantErr, err)
+}
@@ -0,0 +1,14 @@
+package hedge
+
+// Idempotent is a zero-size marker type that callers can embed in the type
+// backing their goresilience.Func to self-document that the operation is
+// safe to execute more than once concurrently. The hedge runner may start
+// several concurrent attempts of the same Func, so it must be idempotent;
+// embedding this type doesn't change behaviour, it's only meant to make
+// that requirement explicit at the call site, for example:
+//
+//	type getUser struct {
+//		hedge.Idempotent
+//		id string
+//	}
+type Idempotent struct{}
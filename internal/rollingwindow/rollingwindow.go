@@ -0,0 +1,69 @@
+// Package rollingwindow factors out the bucket-expiry bookkeeping shared by
+// every fixed-size, time-bucketed rolling window in this module
+// (ratelimit's sliding window counter, hedge's adaptive delay latency
+// window and circuitbreaker's error rate window). It only tracks which
+// bucket is current and which ones have aged out, callers own the actual
+// per-bucket contents (a counter, a slice of samples...) since those differ
+// in shape across users.
+package rollingwindow
+
+import "time"
+
+// Cursor tracks the current bucket of a fixed-size, time-bucketed window,
+// advancing and expiring stale buckets as time passes. It is not safe for
+// concurrent use, callers are expected to guard it with their own lock
+// alongside whatever per-bucket state they keep in sync with it.
+type Cursor struct {
+	bucketDur time.Duration
+	since     []time.Time
+	pos       int
+}
+
+// NewCursor returns a Cursor over n buckets, each covering bucketDur, all
+// considered to start now.
+func NewCursor(n int, bucketDur time.Duration, now time.Time) *Cursor {
+	since := make([]time.Time, n)
+	for i := range since {
+		since[i] = now
+	}
+	return &Cursor{bucketDur: bucketDur, since: since}
+}
+
+// Pos returns the index of the current bucket.
+func (c *Cursor) Pos() int {
+	return c.pos
+}
+
+// Advance moves the cursor forward to now, returning the indices of the
+// buckets that got expired along the way, oldest first, the last one being
+// the new current bucket (also returned by Pos). Callers must reset
+// whatever per-bucket state they keep for each returned index. Returns nil
+// if no bucket has expired yet.
+func (c *Cursor) Advance(now time.Time) []int {
+	elapsed := now.Sub(c.since[c.pos])
+	steps := int(elapsed / c.bucketDur)
+	if steps <= 0 {
+		return nil
+	}
+	if steps > len(c.since) {
+		steps = len(c.since)
+	}
+
+	expired := make([]int, 0, steps)
+	for i := 0; i < steps; i++ {
+		c.pos = (c.pos + 1) % len(c.since)
+		c.since[c.pos] = now
+		expired = append(expired, c.pos)
+	}
+	return expired
+}
+
+// Reset collapses the window back to a single live bucket starting at now,
+// used when a caller wants to wipe all accumulated state (for example a
+// circuit breaker closing again after a successful half-open probe).
+func (c *Cursor) Reset(now time.Time) {
+	for i := range c.since {
+		c.since[i] = now
+	}
+	c.pos = 0
+}
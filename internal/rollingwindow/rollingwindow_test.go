@@ -0,0 +1,42 @@
+package rollingwindow_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rafet/goresilience/internal/rollingwindow"
+)
+
+func TestCursorAdvanceExpiresStaleBuckets(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Now()
+	c := rollingwindow.NewCursor(3, time.Second, start)
+
+	assert.Nil(c.Advance(start), "no bucket should expire before bucketDur has passed")
+	assert.Equal(0, c.Pos())
+
+	expired := c.Advance(start.Add(2500 * time.Millisecond))
+	assert.Equal([]int{1, 2}, expired)
+	assert.Equal(2, c.Pos())
+
+	// Elapsing more than the whole window clamps to the bucket count instead
+	// of wrapping around several times.
+	expired = c.Advance(start.Add(20 * time.Second))
+	assert.Len(expired, 3)
+	assert.Equal(2, c.Pos())
+}
+
+func TestCursorReset(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Now()
+	c := rollingwindow.NewCursor(3, time.Second, start)
+	c.Advance(start.Add(2500 * time.Millisecond))
+
+	c.Reset(start)
+	assert.Equal(0, c.Pos())
+	assert.Nil(c.Advance(start))
+}
@@ -0,0 +1,19 @@
+package goresilience
+
+// Introspectable is implemented by runners that can report their current
+// state independently of the request flow, so it can be sampled at any time
+// (for example by a Prometheus collector on every scrape) instead of only
+// when an execution happens to go through. See the metrics package for a
+// Collector that walks a set of registered Introspectable runners.
+type Introspectable interface {
+	// State returns a snapshot of the runner's live state:
+	//   - breakerState is only meaningful for circuit breakers ("closed",
+	//     "open" or "half-open"), empty otherwise.
+	//   - inflight is the number of executions currently running
+	//     (bulkheads), 0 for runners that don't track it.
+	//   - queueDepth is the number of executions currently waiting for a
+	//     free slot (bulkheads), 0 for runners that don't queue.
+	//   - errorRate is the error rate (0-1) over the runner's own sliding
+	//     window, 0 for runners that don't track one.
+	State() (breakerState string, inflight int, queueDepth int, errorRate float64)
+}
@@ -0,0 +1,26 @@
+package metrics
+
+import "context"
+
+type contextKey int
+
+const recorderContextKey contextKey = iota
+
+// ContextWithRecorder returns a new context that carries the received
+// Recorder, this is used by NewMiddleware to make the Recorder available to
+// the rest of the runner chain.
+func ContextWithRecorder(ctx context.Context, r Recorder) context.Context {
+	return context.WithValue(ctx, recorderContextKey, r)
+}
+
+// RecorderFromContext returns the Recorder stored on the context by a
+// previous metrics.NewMiddleware execution. If there is none it will return
+// the Dummy recorder and false so callers can blindly call the returned
+// recorder without checking the ok value.
+func RecorderFromContext(ctx context.Context) (Recorder, bool) {
+	r, ok := ctx.Value(recorderContextKey).(Recorder)
+	if !ok {
+		return Dummy, false
+	}
+	return r, true
+}
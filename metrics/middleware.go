@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/rafet/goresilience"
+)
+
+// NewMiddleware returns a middleware that will make the received Recorder
+// available to the rest of the runner chain through the context, this is
+// how the different runners (timeout, chaos...) get the Recorder to record
+// their own metrics without needing to receive it explicitly. id is not
+// used: the Recorder is stored on the context as is, it isn't scoped or
+// labelled by id.
+func NewMiddleware(id string, rec Recorder) goresilience.Middleware {
+	return func(next goresilience.Runner) goresilience.Runner {
+		next = goresilience.SanitizeRunner(next)
+		return goresilience.RunnerFunc(func(ctx context.Context, f goresilience.Func) error {
+			ctx = ContextWithRecorder(ctx, rec)
+			return next.Run(ctx, f)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusRecorder is a Recorder that records all the measures using
+// Prometheus metrics.
+type PrometheusRecorder struct {
+	timeouts            *prometheus.CounterVec
+	chaosInjection      *prometheus.CounterVec
+	rateLimited         *prometheus.CounterVec
+	rateLimitWaitS      *prometheus.HistogramVec
+	bulkheadWaitTimeout *prometheus.CounterVec
+	retries             *prometheus.CounterVec
+	hedgeLaunched       *prometheus.CounterVec
+	hedgeWon            *prometheus.CounterVec
+	fallbacks           *prometheus.CounterVec
+	bulkheadLimit       *prometheus.GaugeVec
+	bulkheadInflight    *prometheus.GaugeVec
+}
+
+// NewPrometheusRecorder returns a new Recorder that records using Prometheus
+// and registers its metrics on the received registerer.
+func NewPrometheusRecorder(reg prometheus.Registerer) *PrometheusRecorder {
+	r := &PrometheusRecorder{
+		timeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "goresilience",
+			Subsystem: "timeout",
+			Name:      "timeouts_total",
+			Help:      "Total number of timed out executions.",
+		}, []string{}),
+		chaosInjection: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "goresilience",
+			Subsystem: "chaos",
+			Name:      "injected_failures_total",
+			Help:      "Total number of failures injected by kind.",
+		}, []string{"kind"}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "goresilience",
+			Subsystem: "ratelimit",
+			Name:      "limited_total",
+			Help:      "Total number of executions rejected by the rate limit runner.",
+		}, []string{"algorithm", "key"}),
+		rateLimitWaitS: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "goresilience",
+			Subsystem: "ratelimit",
+			Name:      "wait_seconds",
+			Help:      "Time an execution has waited for a free slot on the rate limit runner.",
+		}, []string{}),
+		bulkheadWaitTimeout: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "goresilience",
+			Subsystem: "bulkhead",
+			Name:      "wait_timeouts_total",
+			Help:      "Total number of executions that gave up waiting for a free worker.",
+		}, []string{}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "goresilience",
+			Subsystem: "retry",
+			Name:      "retries_total",
+			Help:      "Total number of retried executions.",
+		}, []string{}),
+		hedgeLaunched: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "goresilience",
+			Subsystem: "hedge",
+			Name:      "launched_total",
+			Help:      "Total number of attempts launched by the hedge runner.",
+		}, []string{}),
+		hedgeWon: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "goresilience",
+			Subsystem: "hedge",
+			Name:      "won_total",
+			Help:      "Total number of times an attempt has won, by attempt index.",
+		}, []string{"attempt"}),
+		fallbacks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "goresilience",
+			Subsystem: "fallback",
+			Name:      "executed_total",
+			Help:      "Total number of times the fallback runner executed its fallback Func, by reason.",
+		}, []string{"reason"}),
+		bulkheadLimit: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "goresilience",
+			Subsystem: "bulkhead",
+			Name:      "limit",
+			Help:      "Current concurrency limit of an adaptive bulkhead.",
+		}, []string{}),
+		bulkheadInflight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "goresilience",
+			Subsystem: "bulkhead",
+			Name:      "inflight",
+			Help:      "Current number of in-flight executions of an adaptive bulkhead.",
+		}, []string{}),
+	}
+
+	reg.MustRegister(
+		r.timeouts,
+		r.chaosInjection,
+		r.rateLimited,
+		r.rateLimitWaitS,
+		r.bulkheadWaitTimeout,
+		r.retries,
+		r.hedgeLaunched,
+		r.hedgeWon,
+		r.fallbacks,
+		r.bulkheadLimit,
+		r.bulkheadInflight,
+	)
+
+	return r
+}
+
+// IncTimeout satisfies Recorder interface.
+func (p *PrometheusRecorder) IncTimeout() {
+	p.timeouts.WithLabelValues().Inc()
+}
+
+// IncChaosInjectedFailure satisfies Recorder interface.
+func (p *PrometheusRecorder) IncChaosInjectedFailure(kind string) {
+	p.chaosInjection.WithLabelValues(kind).Inc()
+}
+
+// IncRateLimited satisfies Recorder interface.
+func (p *PrometheusRecorder) IncRateLimited(algorithm, key string) {
+	p.rateLimited.WithLabelValues(algorithm, key).Inc()
+}
+
+// ObserveRateLimitWait satisfies Recorder interface.
+func (p *PrometheusRecorder) ObserveRateLimitWait(wait time.Duration) {
+	p.rateLimitWaitS.WithLabelValues().Observe(wait.Seconds())
+}
+
+// IncBulkheadWaitTimeout satisfies Recorder interface.
+func (p *PrometheusRecorder) IncBulkheadWaitTimeout() {
+	p.bulkheadWaitTimeout.WithLabelValues().Inc()
+}
+
+// IncRetry satisfies Recorder interface.
+func (p *PrometheusRecorder) IncRetry() {
+	p.retries.WithLabelValues().Inc()
+}
+
+// IncHedgeLaunched satisfies Recorder interface.
+func (p *PrometheusRecorder) IncHedgeLaunched() {
+	p.hedgeLaunched.WithLabelValues().Inc()
+}
+
+// IncHedgeWon satisfies Recorder interface.
+func (p *PrometheusRecorder) IncHedgeWon(attemptIdx int) {
+	p.hedgeWon.WithLabelValues(strconv.Itoa(attemptIdx)).Inc()
+}
+
+// IncFallback satisfies Recorder interface.
+func (p *PrometheusRecorder) IncFallback(reason string) {
+	p.fallbacks.WithLabelValues(reason).Inc()
+}
+
+// ObserveBulkheadLimit satisfies Recorder interface.
+func (p *PrometheusRecorder) ObserveBulkheadLimit(limit int) {
+	p.bulkheadLimit.WithLabelValues().Set(float64(limit))
+}
+
+// ObserveBulkheadInflight satisfies Recorder interface.
+func (p *PrometheusRecorder) ObserveBulkheadInflight(inflight int) {
+	p.bulkheadInflight.WithLabelValues().Set(float64(inflight))
+}
@@ -0,0 +1,68 @@
+// Package metrics knows how to measure what happens on the different
+// goresilience runners. A Recorder is obtained for every execution through
+// the context (see RecorderFromContext), where NewMiddleware stores it for
+// the rest of the runner chain. The recording methods take no id/name
+// argument, so every runner in a chain records on the same Recorder.
+package metrics
+
+import "time"
+
+// Recorder knows how to record the different events that happen on the
+// runners of this library. Implementations must be safe for concurrent use.
+type Recorder interface {
+	// IncTimeout will increment the number of timed out executions.
+	IncTimeout()
+	// IncChaosInjectedFailure will increment the number of failures that
+	// have been injected by the chaos runner, by kind of failure.
+	IncChaosInjectedFailure(kind string)
+	// IncRateLimited will increment the number of executions rejected by
+	// the rate limit runner, by algorithm and key (key is empty when the
+	// runner isn't using per-key limiting).
+	IncRateLimited(algorithm, key string)
+	// ObserveRateLimitWait will measure the time an execution has waited
+	// for a free slot on the rate limit runner before being allowed to run.
+	ObserveRateLimitWait(wait time.Duration)
+	// IncBulkheadWaitTimeout will increment the number of executions that
+	// gave up waiting for a free worker on the bulkhead runner.
+	IncBulkheadWaitTimeout()
+	// IncRetry will increment the number of retried executions on the retry
+	// runner.
+	IncRetry()
+	// IncHedgeLaunched will increment the number of attempts launched by the
+	// hedge runner, including the first, non-speculative, one.
+	IncHedgeLaunched()
+	// IncHedgeWon will increment the number of times the attempt at
+	// attemptIdx (0 is the first, non-speculative, attempt) has been the
+	// one that won (finished first without error) on the hedge runner.
+	IncHedgeWon(attemptIdx int)
+	// IncFallback will increment the number of times the fallback runner
+	// has executed its fallback Func, by reason (the error that triggered
+	// it).
+	IncFallback(reason string)
+	// ObserveBulkheadLimit reports the current concurrency limit of an
+	// adaptive bulkhead.
+	ObserveBulkheadLimit(limit int)
+	// ObserveBulkheadInflight reports the current number of in-flight
+	// executions of an adaptive bulkhead.
+	ObserveBulkheadInflight(inflight int)
+}
+
+// dummy is a Recorder that doesn't record anything, it's used as a fallback
+// when no Recorder has been set on the context.
+type dummy struct{}
+
+// Dummy is a Recorder that doesn't do anything, it's the default recorder
+// used when the user doesn't set one.
+var Dummy Recorder = dummy{}
+
+func (dummy) IncTimeout()                          {}
+func (dummy) IncChaosInjectedFailure(_ string)     {}
+func (dummy) IncRateLimited(_, _ string)           {}
+func (dummy) ObserveRateLimitWait(_ time.Duration) {}
+func (dummy) IncBulkheadWaitTimeout()              {}
+func (dummy) IncRetry()                            {}
+func (dummy) IncHedgeLaunched()                    {}
+func (dummy) IncHedgeWon(_ int)                    {}
+func (dummy) IncFallback(_ string)                 {}
+func (dummy) ObserveBulkheadLimit(_ int)           {}
+func (dummy) ObserveBulkheadInflight(_ int)        {}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/rafet/goresilience"
+)
+
+// tokenReporter mirrors ratelimit.TokenReporter structurally, it's redeclared
+// here (instead of imported) to avoid a dependency from metrics onto the
+// individual runner packages.
+type tokenReporter interface {
+	Tokens() map[string]float64
+}
+
+// queueReporter mirrors bulkhead.QueueReporter structurally, for the same
+// reason as tokenReporter above.
+type queueReporter interface {
+	Queue() (inflight int, queueDepth int)
+}
+
+// StateCollector is a prometheus.Collector that pull-samples the live state
+// of its registered runners on every scrape, instead of only recording
+// counters at event time like Recorder does. This lets operators see
+// steady-state health (a breaker stuck open, a bulkhead permanently full)
+// even during a period with no traffic at all.
+type StateCollector struct {
+	mu      sync.Mutex
+	runners map[string]goresilience.Introspectable
+
+	circuitState     *prometheus.Desc
+	bulkheadInflight *prometheus.Desc
+	bulkheadQueue    *prometheus.Desc
+	ratelimitTokens  *prometheus.Desc
+}
+
+// NewStateCollector returns a new, empty StateCollector, runners are added to
+// it with Register.
+func NewStateCollector() *StateCollector {
+	return &StateCollector{
+		runners: map[string]goresilience.Introspectable{},
+		circuitState: prometheus.NewDesc(
+			"goresilience_circuit_state",
+			"Current circuit breaker state (0 closed, 1 half-open, 2 open).",
+			[]string{"name"}, nil,
+		),
+		bulkheadInflight: prometheus.NewDesc(
+			"goresilience_bulkhead_inflight",
+			"Current number of in-flight executions.",
+			[]string{"name"}, nil,
+		),
+		bulkheadQueue: prometheus.NewDesc(
+			"goresilience_bulkhead_queue_depth",
+			"Current number of executions waiting for a free slot.",
+			[]string{"name"}, nil,
+		),
+		ratelimitTokens: prometheus.NewDesc(
+			"goresilience_ratelimit_tokens",
+			"Fraction (0-1) of rate limit capacity currently available, by key.",
+			[]string{"name", "key"}, nil,
+		),
+	}
+}
+
+// Register adds (or replaces) the Introspectable runner sampled under name
+// on every scrape.
+func (c *StateCollector) Register(name string, r goresilience.Introspectable) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.runners[name] = r
+}
+
+// Unregister removes the runner registered under name, it's a no-op if none
+// was registered.
+func (c *StateCollector) Unregister(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.runners, name)
+}
+
+// Describe satisfies prometheus.Collector.
+func (c *StateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.circuitState
+	ch <- c.bulkheadInflight
+	ch <- c.bulkheadQueue
+	ch <- c.ratelimitTokens
+}
+
+// Collect satisfies prometheus.Collector, walking the registered runners and
+// sampling their current state.
+func (c *StateCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	runners := make(map[string]goresilience.Introspectable, len(c.runners))
+	for name, r := range c.runners {
+		runners[name] = r
+	}
+	c.mu.Unlock()
+
+	for name, r := range runners {
+		breakerState, _, _, _ := r.State()
+
+		if breakerState != "" {
+			ch <- prometheus.MustNewConstMetric(c.circuitState, prometheus.GaugeValue, circuitStateValue(breakerState), name)
+		}
+
+		if qr, ok := r.(queueReporter); ok {
+			inflight, queueDepth := qr.Queue()
+			ch <- prometheus.MustNewConstMetric(c.bulkheadInflight, prometheus.GaugeValue, float64(inflight), name)
+			ch <- prometheus.MustNewConstMetric(c.bulkheadQueue, prometheus.GaugeValue, float64(queueDepth), name)
+		}
+
+		if tr, ok := r.(tokenReporter); ok {
+			for key, tokens := range tr.Tokens() {
+				ch <- prometheus.MustNewConstMetric(c.ratelimitTokens, prometheus.GaugeValue, tokens, name, key)
+			}
+		}
+	}
+}
+
+func circuitStateValue(s string) float64 {
+	switch s {
+	case "half-open":
+		return 1
+	case "open":
+		return 2
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,81 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rafet/goresilience"
+	"github.com/rafet/goresilience/metrics"
+)
+
+type fakeRunner struct {
+	breakerState string
+	inflight     int
+	queueDepth   int
+	tokens       map[string]float64
+}
+
+func (f fakeRunner) State() (string, int, int, float64) {
+	return f.breakerState, f.inflight, f.queueDepth, 0
+}
+
+func (f fakeRunner) Tokens() map[string]float64 { return f.tokens }
+
+// fakeBulkheadRunner is the only fake in this file implementing Queue, the
+// way bulkhead.New and bulkhead.NewAdaptive runners do.
+type fakeBulkheadRunner struct {
+	inflight   int
+	queueDepth int
+}
+
+func (f fakeBulkheadRunner) State() (string, int, int, float64) {
+	return "", f.inflight, f.queueDepth, 0
+}
+func (f fakeBulkheadRunner) Queue() (int, int) { return f.inflight, f.queueDepth }
+
+func TestStateCollectorCollectsRegisteredRunners(t *testing.T) {
+	assert := assert.New(t)
+
+	collector := metrics.NewStateCollector()
+	collector.Register("breaker", fakeRunner{breakerState: "open"})
+	collector.Register("limiter", fakeRunner{tokens: map[string]float64{"tenant-a": 0.5}})
+	collector.Register("bulkhead", fakeBulkheadRunner{inflight: 3, queueDepth: 2})
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(collector)
+
+	got, err := reg.Gather()
+	assert.NoError(err)
+
+	families := map[string]*dto.MetricFamily{}
+	for _, mf := range got {
+		families[mf.GetName()] = mf
+	}
+
+	assert.Contains(families, "goresilience_circuit_state")
+	assert.Contains(families, "goresilience_ratelimit_tokens")
+
+	// Only the runner that actually implements Queue should produce bulkhead
+	// gauges, "breaker" and "limiter" don't track a queue and shouldn't show
+	// up as misleading always-zero bulkhead series.
+	if assert.Contains(families, "goresilience_bulkhead_inflight") {
+		metricNames := map[string]bool{}
+		for _, m := range families["goresilience_bulkhead_inflight"].GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "name" {
+					metricNames[l.GetValue()] = true
+				}
+			}
+		}
+		assert.Equal(map[string]bool{"bulkhead": true}, metricNames)
+	}
+}
+
+var (
+	_ goresilience.Introspectable = fakeRunner{}
+	_ goresilience.Introspectable = fakeBulkheadRunner{}
+	_ prometheus.Collector        = metrics.NewStateCollector()
+)
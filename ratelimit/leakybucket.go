@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// leakyBucket implements the leaky bucket algorithm: executions fill a
+// bounded queue of QueueSize and leak (drain) at a constant Rate per
+// Interval, executions are rejected once the queue is full.
+type leakyBucket struct {
+	mu        sync.Mutex
+	queued    float64
+	queueSize float64
+	leakRate  float64 // slots drained per nanosecond.
+	last      time.Time
+}
+
+func newLeakyBucket(cfg Config) *leakyBucket {
+	return &leakyBucket{
+		queueSize: float64(cfg.QueueSize),
+		leakRate:  float64(cfg.Rate) / float64(cfg.Interval),
+		last:      time.Now(),
+	}
+}
+
+func (l *leakyBucket) allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(l.last)
+	l.last = now
+
+	l.queued -= float64(elapsed) * l.leakRate
+	if l.queued < 0 {
+		l.queued = 0
+	}
+
+	if l.queued >= l.queueSize {
+		return false
+	}
+
+	l.queued++
+	return true
+}
+
+// remaining returns the fraction (0-1) of the queue capacity currently free.
+func (l *leakyBucket) remaining() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.queueSize <= 0 {
+		return 0
+	}
+	queued := l.queued - float64(time.Since(l.last))*l.leakRate
+	if queued < 0 {
+		queued = 0
+	}
+	return (l.queueSize - queued) / l.queueSize
+}
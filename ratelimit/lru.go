@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lru is a fixed size, least-recently-used cache of per-key algorithm
+// state, used to bound the memory used by per-key rate limiting.
+type lru struct {
+	mu       sync.Mutex
+	maxItems int
+	newItem  func() algorithm
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+type lruEntry struct {
+	key string
+	alg algorithm
+}
+
+func newLRU(maxItems int, newItem func() algorithm) *lru {
+	return &lru{
+		maxItems: maxItems,
+		newItem:  newItem,
+		items:    map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// getOrCreate returns the algorithm state for key, creating it if it's the
+// first time key is seen, and evicting the least recently used key if that
+// makes the cache grow beyond maxItems.
+func (l *lru) getOrCreate(key string) algorithm {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.items[key]; ok {
+		l.order.MoveToFront(el)
+		return el.Value.(*lruEntry).alg
+	}
+
+	entry := &lruEntry{key: key, alg: l.newItem()}
+	el := l.order.PushFront(entry)
+	l.items[key] = el
+
+	if l.order.Len() > l.maxItems {
+		oldest := l.order.Back()
+		if oldest != nil {
+			l.order.Remove(oldest)
+			delete(l.items, oldest.Value.(*lruEntry).key)
+		}
+	}
+
+	return entry.alg
+}
+
+// snapshot returns a copy of the currently live key -> algorithm state, safe
+// to range over without holding the lru's lock.
+func (l *lru) snapshot() map[string]algorithm {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := make(map[string]algorithm, len(l.items))
+	for key, el := range l.items {
+		out[key] = el.Value.(*lruEntry).alg
+	}
+	return out
+}
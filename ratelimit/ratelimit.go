@@ -0,0 +1,287 @@
+// Package ratelimit provides a goresilience.Middleware that limits the rate
+// of executions using a pluggable algorithm (token bucket, leaky bucket or
+// a sliding window counter).
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/rafet/goresilience"
+	"github.com/rafet/goresilience/errors"
+	"github.com/rafet/goresilience/metrics"
+)
+
+// Algorithm is the kind of rate limiting algorithm that will be used.
+type Algorithm string
+
+const (
+	// AlgorithmTokenBucket limits using a token bucket, refilling `Rate`
+	// tokens every `Interval` up to `Burst` capacity, consuming a token per
+	// execution.
+	AlgorithmTokenBucket Algorithm = "token-bucket"
+	// AlgorithmLeakyBucket limits using a leaky bucket, queueing up to
+	// `QueueSize` executions and draining them at a constant `Rate` per
+	// `Interval`.
+	AlgorithmLeakyBucket Algorithm = "leaky-bucket"
+	// AlgorithmSlidingWindow limits using a sliding window counter made of
+	// `Buckets` sub-buckets covering `Window`, rejecting when the sum of
+	// all the sub-buckets reaches `Rate`.
+	AlgorithmSlidingWindow Algorithm = "sliding-window"
+)
+
+// Mode decides what happens when the limit has been reached.
+type Mode int
+
+const (
+	// ModeReject will return errors.ErrRateLimited as soon as the limit has
+	// been reached.
+	ModeReject Mode = iota
+	// ModeWait will block the execution up to MaxWait waiting for a free
+	// slot, returning errors.ErrRateLimited if that time passes.
+	ModeWait
+)
+
+const (
+	defaultRate     = 100
+	defaultInterval = 1 * time.Second
+	defaultBurst    = 100
+	defaultQueue    = 100
+	defaultWindow   = 1 * time.Second
+	defaultBuckets  = 10
+	defaultMaxKeys  = 1000
+	defaultMaxWait  = 1 * time.Second
+)
+
+// Config is the configuration of the rate limit runner.
+type Config struct {
+	// Algorithm is the rate limiting algorithm that will be used, defaults
+	// to AlgorithmTokenBucket.
+	Algorithm Algorithm
+	// Rate is the number of allowed executions per Interval (token bucket
+	// refill rate, leaky bucket drain rate), or per Window for
+	// AlgorithmSlidingWindow (sliding window limit).
+	Rate int
+	// Interval is the unit of time Rate is measured in, not used by
+	// AlgorithmSlidingWindow.
+	Interval time.Duration
+	// Burst is the token bucket capacity, only used by AlgorithmTokenBucket.
+	Burst int
+	// QueueSize is the bounded queue size, only used by AlgorithmLeakyBucket.
+	QueueSize int
+	// Window is the total duration covered by the sliding window, only used
+	// by AlgorithmSlidingWindow.
+	Window time.Duration
+	// Buckets is the number of sub-buckets Window is split into, only used
+	// by AlgorithmSlidingWindow.
+	Buckets int
+	// Mode decides what happens when the limit has been reached, defaults
+	// to ModeReject.
+	Mode Mode
+	// MaxWait is the max time an execution will wait for a free slot when
+	// Mode is ModeWait, defaults to 1 second.
+	MaxWait time.Duration
+	// KeyFunc, when set, enables per-key rate limiting (for example per
+	// tenant or user), a different limiter state is kept per returned key.
+	KeyFunc func(ctx context.Context) string
+	// MaxKeys is the maximum number of per-key limiter states kept alive at
+	// the same time, the least recently used ones are evicted once the
+	// limit is reached. Only used when KeyFunc is set.
+	MaxKeys int
+}
+
+func (c *Config) defaults() {
+	if c.Algorithm == "" {
+		c.Algorithm = AlgorithmTokenBucket
+	}
+	if c.Rate <= 0 {
+		c.Rate = defaultRate
+	}
+	if c.Interval <= 0 {
+		c.Interval = defaultInterval
+	}
+	if c.Burst <= 0 {
+		c.Burst = defaultBurst
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = defaultQueue
+	}
+	if c.Window <= 0 {
+		c.Window = defaultWindow
+	}
+	if c.Buckets <= 0 {
+		c.Buckets = defaultBuckets
+	}
+	if c.MaxKeys <= 0 {
+		c.MaxKeys = defaultMaxKeys
+	}
+	if c.MaxWait <= 0 {
+		c.MaxWait = defaultMaxWait
+	}
+}
+
+// Limiter knows how to decide if an execution is allowed to proceed. It's a
+// standalone interface decoupled from the runner so it can be created once
+// and shared across several runners, the same way chaos.Injector is shared
+// across chaos runners.
+type Limiter interface {
+	// Allow blocks (depending on the Mode it was created with) until the
+	// execution is allowed to proceed or returns errors.ErrRateLimited.
+	Allow(ctx context.Context) error
+}
+
+// NewLimiter returns a standalone Limiter that can be shared by several
+// runners, for example to limit several `goresilience.Runner`s against the
+// same budget.
+func NewLimiter(cfg Config) Limiter {
+	cfg.defaults()
+	return &limiter{
+		cfg: cfg,
+		alg: newAlgorithm(cfg),
+	}
+}
+
+type limiter struct {
+	cfg Config
+	alg algorithm
+}
+
+func (l *limiter) Allow(ctx context.Context) error {
+	metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+	return allow(ctx, l.cfg, l.alg, "", metricsRecorder)
+}
+
+// algorithm is the internal abstraction implemented by each rate limiting
+// strategy, it only needs to answer if a single execution is allowed right
+// now and how much of its capacity is currently free.
+type algorithm interface {
+	allow() bool
+	// remaining returns the fraction (0-1) of the algorithm's own capacity
+	// (tokens, queue slots or window budget) that is currently available.
+	remaining() float64
+}
+
+func newAlgorithm(cfg Config) algorithm {
+	switch cfg.Algorithm {
+	case AlgorithmLeakyBucket:
+		return newLeakyBucket(cfg)
+	case AlgorithmSlidingWindow:
+		return newSlidingWindow(cfg)
+	default:
+		return newTokenBucket(cfg)
+	}
+}
+
+// allow applies cfg.Mode on top of a raw algorithm, rejecting immediately or
+// waiting up to cfg.MaxWait for a free slot, and records the outcome on rec.
+func allow(ctx context.Context, cfg Config, alg algorithm, key string, rec metrics.Recorder) error {
+	if cfg.Mode != ModeWait {
+		if alg.allow() {
+			return nil
+		}
+		rec.IncRateLimited(string(cfg.Algorithm), key)
+		return errors.ErrRateLimited
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, cfg.MaxWait)
+	defer cancel()
+
+	ticker := time.NewTicker(backoffStep(cfg))
+	defer ticker.Stop()
+
+	for {
+		if alg.allow() {
+			rec.ObserveRateLimitWait(time.Since(start))
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			rec.IncRateLimited(string(cfg.Algorithm), key)
+			return errors.ErrRateLimited
+		}
+	}
+}
+
+// backoffStep returns how often we retry asking the algorithm for a free
+// slot while waiting, a fraction of the configured Interval.
+func backoffStep(cfg Config) time.Duration {
+	step := cfg.Interval / time.Duration(cfg.Rate+1)
+	if step <= 0 {
+		step = time.Millisecond
+	}
+	return step
+}
+
+// New returns a new goresilience.Runner that rate limits using the provided
+// Config, use zero values for default settings.
+func New(cfg Config) goresilience.Runner {
+	return NewMiddleware(cfg)(nil)
+}
+
+// TokenReporter is implemented by rate limit runners, exposing the fraction
+// of capacity currently available per key (the empty string is the key used
+// when Config.KeyFunc isn't set). It's narrower than
+// goresilience.Introspectable because a single State() call can't carry a
+// per-key breakdown.
+type TokenReporter interface {
+	// Tokens returns the fraction (0-1) of capacity currently available, by
+	// key.
+	Tokens() map[string]float64
+}
+
+type rateLimiter struct {
+	cfg    Config
+	runner goresilience.Runner
+	keys   *lru
+}
+
+// NewMiddleware returns a middleware that rejects (or waits, depending on
+// Mode) the executions once the configured rate limit has been reached. When
+// cfg.KeyFunc is set the limit is applied per key, keeping at most
+// cfg.MaxKeys live algorithm states at the same time.
+func NewMiddleware(cfg Config) goresilience.Middleware {
+	cfg.defaults()
+
+	return func(next goresilience.Runner) goresilience.Runner {
+		return &rateLimiter{
+			cfg:    cfg,
+			runner: goresilience.SanitizeRunner(next),
+			keys:   newLRU(cfg.MaxKeys, func() algorithm { return newAlgorithm(cfg) }),
+		}
+	}
+}
+
+func (r *rateLimiter) Run(ctx context.Context, f goresilience.Func) error {
+	metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+
+	key := ""
+	if r.cfg.KeyFunc != nil {
+		key = r.cfg.KeyFunc(ctx)
+	}
+
+	alg := r.keys.getOrCreate(key)
+	if err := allow(ctx, r.cfg, alg, key, metricsRecorder); err != nil {
+		return err
+	}
+
+	return r.runner.Run(ctx, f)
+}
+
+// State satisfies goresilience.Introspectable. The per-key breakdown isn't
+// representable here, see Tokens.
+func (r *rateLimiter) State() (breakerState string, inflight int, queueDepth int, errorRate float64) {
+	return "", 0, 0, 0
+}
+
+// Tokens satisfies TokenReporter.
+func (r *rateLimiter) Tokens() map[string]float64 {
+	snapshot := r.keys.snapshot()
+	out := make(map[string]float64, len(snapshot))
+	for key, alg := range snapshot {
+		out[key] = alg.remaining()
+	}
+	return out
+}
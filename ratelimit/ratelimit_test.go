@@ -0,0 +1,129 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rafet/goresilience"
+	"github.com/rafet/goresilience/ratelimit"
+)
+
+func TestRateLimitReject(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          ratelimit.Config
+		timesToCall  int
+		expTotalOK   int
+		expTotalErrs int
+	}{
+		{
+			name: "A token bucket without burst left should reject the rest of the calls.",
+			cfg: ratelimit.Config{
+				Algorithm: ratelimit.AlgorithmTokenBucket,
+				Rate:      1,
+				Interval:  time.Second,
+				Burst:     5,
+			},
+			timesToCall:  20,
+			expTotalOK:   5,
+			expTotalErrs: 15,
+		},
+		{
+			name: "A leaky bucket with a full queue should reject the rest of the calls.",
+			cfg: ratelimit.Config{
+				Algorithm: ratelimit.AlgorithmLeakyBucket,
+				Rate:      1,
+				Interval:  time.Second,
+				QueueSize: 5,
+			},
+			timesToCall:  20,
+			expTotalOK:   5,
+			expTotalErrs: 15,
+		},
+		{
+			name: "A sliding window at its limit should reject the rest of the calls.",
+			cfg: ratelimit.Config{
+				Algorithm: ratelimit.AlgorithmSlidingWindow,
+				Rate:      5,
+				Window:    time.Second,
+				Buckets:   10,
+			},
+			timesToCall:  20,
+			expTotalOK:   5,
+			expTotalErrs: 15,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			runner := ratelimit.New(test.cfg)
+
+			gotOK := 0
+			gotErrs := 0
+			for i := 0; i < test.timesToCall; i++ {
+				err := runner.Run(context.TODO(), func(_ context.Context) error { return nil })
+				if err != nil {
+					gotErrs++
+				} else {
+					gotOK++
+				}
+			}
+
+			// Leave a bit of slack since the algorithms are time based and
+			// some time passes between the calls in the loop above.
+			assert.InDelta(test.expTotalOK, gotOK, 1)
+			assert.InDelta(test.expTotalErrs, gotErrs, 1)
+		})
+	}
+}
+
+func TestRateLimitPerKey(t *testing.T) {
+	assert := assert.New(t)
+
+	runner := ratelimit.New(ratelimit.Config{
+		Rate:  1,
+		Burst: 1,
+		KeyFunc: func(ctx context.Context) string {
+			key, _ := ctx.Value(ctxKey{}).(string)
+			return key
+		},
+	})
+
+	run := func(key string) error {
+		ctx := context.WithValue(context.Background(), ctxKey{}, key)
+		return runner.Run(ctx, func(_ context.Context) error { return nil })
+	}
+
+	assert.NoError(run("tenant-a"))
+	assert.Error(run("tenant-a"), "the second call for the same key should be rejected")
+	assert.NoError(run("tenant-b"), "a different key should have its own budget")
+}
+
+// TestRateLimitModeWaitDefaultsMaxWait checks that ModeWait actually waits
+// for a free slot when MaxWait is left unset, instead of behaving like
+// ModeReject (WithTimeout(ctx, 0) is immediately done).
+func TestRateLimitModeWaitDefaultsMaxWait(t *testing.T) {
+	assert := assert.New(t)
+
+	runner := ratelimit.New(ratelimit.Config{
+		Algorithm: ratelimit.AlgorithmTokenBucket,
+		Rate:      1,
+		Interval:  20 * time.Millisecond,
+		Burst:     1,
+		Mode:      ratelimit.ModeWait,
+	})
+
+	assert.NoError(runner.Run(context.TODO(), func(_ context.Context) error { return nil }))
+	// The bucket is empty now, a waiting call should still succeed once a
+	// token is refilled instead of being rejected on the first miss.
+	assert.NoError(runner.Run(context.TODO(), func(_ context.Context) error { return nil }))
+}
+
+type ctxKey struct{}
+
+var _ goresilience.Runner = ratelimit.New(ratelimit.Config{})
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rafet/goresilience/internal/rollingwindow"
+)
+
+// slidingWindow implements a sliding window counter: Window is split into
+// Buckets sub-buckets, each covering Window/Buckets, an execution is
+// rejected once the sum of all the currently valid sub-buckets reaches
+// Rate.
+type slidingWindow struct {
+	mu      sync.Mutex
+	cursor  *rollingwindow.Cursor
+	buckets []int
+	limit   int
+}
+
+func newSlidingWindow(cfg Config) *slidingWindow {
+	bucketDur := cfg.Window / time.Duration(cfg.Buckets)
+
+	return &slidingWindow{
+		cursor:  rollingwindow.NewCursor(cfg.Buckets, bucketDur, time.Now()),
+		buckets: make([]int, cfg.Buckets),
+		limit:   cfg.Rate,
+	}
+}
+
+func (s *slidingWindow) allow() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.advance(time.Now())
+
+	total := 0
+	for _, c := range s.buckets {
+		total += c
+	}
+	if total >= s.limit {
+		return false
+	}
+
+	s.buckets[s.cursor.Pos()]++
+	return true
+}
+
+// remaining returns the fraction (0-1) of the window's limit currently free.
+func (s *slidingWindow) remaining() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.limit <= 0 {
+		return 0
+	}
+
+	s.advance(time.Now())
+
+	total := 0
+	for _, c := range s.buckets {
+		total += c
+	}
+	if total >= s.limit {
+		return 0
+	}
+	return float64(s.limit-total) / float64(s.limit)
+}
+
+// advance expires the sub-buckets whose time window has already passed.
+func (s *slidingWindow) advance(now time.Time) {
+	for _, idx := range s.cursor.Advance(now) {
+		s.buckets[idx] = 0
+	}
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket implements the token bucket algorithm: it starts full with
+// Burst tokens and refills Rate tokens every Interval, up to Burst. Every
+// allowed execution consumes one token.
+type tokenBucket struct {
+	mu         sync.Mutex
+	tokens     float64
+	burst      float64
+	refillRate float64 // tokens per nanosecond.
+	last       time.Time
+}
+
+func newTokenBucket(cfg Config) *tokenBucket {
+	return &tokenBucket{
+		tokens:     float64(cfg.Burst),
+		burst:      float64(cfg.Burst),
+		refillRate: float64(cfg.Rate) / float64(cfg.Interval),
+		last:       time.Now(),
+	}
+}
+
+func (t *tokenBucket) allow() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(t.last)
+	t.last = now
+
+	t.tokens += float64(elapsed) * t.refillRate
+	if t.tokens > t.burst {
+		t.tokens = t.burst
+	}
+
+	if t.tokens < 1 {
+		return false
+	}
+
+	t.tokens--
+	return true
+}
+
+// remaining returns the fraction (0-1) of the burst capacity currently
+// available.
+func (t *tokenBucket) remaining() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.burst <= 0 {
+		return 0
+	}
+	tokens := t.tokens + float64(time.Since(t.last))*t.refillRate
+	if tokens > t.burst {
+		tokens = t.burst
+	}
+	return tokens / t.burst
+}
@@ -0,0 +1,110 @@
+// Package retry will retry the execution of a Func when it fails.
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/rafet/goresilience"
+	"github.com/rafet/goresilience/errors"
+	"github.com/rafet/goresilience/metrics"
+)
+
+const (
+	defaultTimes    = 2
+	defaultWaitBase = 10 * time.Millisecond
+)
+
+// Config is the configuration of the retry.
+type Config struct {
+	// Times is the number of times the Func will be executed before giving
+	// up (the first execution + Times-1 retries).
+	Times int
+	// WaitBase is the base duration waited between retries, it's multiplied
+	// by the retry number so every retry waits a bit longer than the
+	// previous one.
+	WaitBase time.Duration
+	// RetryOnCause, when true, also retries executions that failed because
+	// an inner middleware (timeout, bulkhead) cancelled the context, in
+	// addition to regular errors. It never retries a user cancellation.
+	// See goresilience.ClassifyError.
+	RetryOnCause bool
+}
+
+func (c *Config) defaults() {
+	if c.Times <= 0 {
+		c.Times = defaultTimes
+	}
+	if c.WaitBase <= 0 {
+		c.WaitBase = defaultWaitBase
+	}
+}
+
+type retrier struct {
+	cfg    Config
+	runner goresilience.Runner
+}
+
+// New returns a new goresilience.Runner that retries the execution of a
+// Func when it fails, use 0 values for default settings.
+func New(cfg Config) goresilience.Runner {
+	return NewMiddleware(cfg)(nil)
+}
+
+// NewMiddleware returns a middleware that retries the execution of the
+// wrapped runner when it fails.
+func NewMiddleware(cfg Config) goresilience.Middleware {
+	cfg.defaults()
+
+	return func(next goresilience.Runner) goresilience.Runner {
+		return &retrier{
+			cfg:    cfg,
+			runner: goresilience.SanitizeRunner(next),
+		}
+	}
+}
+
+func (r *retrier) Run(ctx context.Context, f goresilience.Func) error {
+	metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+
+	var err error
+	for i := 0; i < r.cfg.Times; i++ {
+		err = r.runner.Run(ctx, f)
+		if err == nil {
+			return nil
+		}
+
+		if !r.shouldRetry(ctx, err) {
+			return err
+		}
+
+		// Don't wait (and retry) if this was the last allowed attempt.
+		if i == r.cfg.Times-1 {
+			break
+		}
+
+		metricsRecorder.IncRetry()
+		select {
+		case <-time.After(r.cfg.WaitBase * time.Duration(i+1)):
+		case <-ctx.Done():
+			return err
+		}
+	}
+
+	return errors.ErrRetriesExhausted
+}
+
+// shouldRetry decides if a failed execution deserves a retry. A user
+// initiated cancellation is never retried, our own middlewares cancellation
+// causes (timeout, bulkhead) are only retried when Config.RetryOnCause is
+// enabled, everything else (regular Func errors) is always retried.
+func (r *retrier) shouldRetry(ctx context.Context, err error) bool {
+	switch goresilience.ClassifyError(ctx, err) {
+	case goresilience.CauseUserCancelled:
+		return false
+	case goresilience.CauseTimeout, goresilience.CauseBulkheadWaitTimeout:
+		return r.cfg.RetryOnCause
+	default:
+		return true
+	}
+}
@@ -0,0 +1,105 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rafet/goresilience"
+	goresilienceerrors "github.com/rafet/goresilience/errors"
+	"github.com/rafet/goresilience/retry"
+	"github.com/rafet/goresilience/timeout"
+)
+
+func TestRetryRetriesRegularErrorsUntilSuccess(t *testing.T) {
+	assert := assert.New(t)
+
+	runner := retry.New(retry.Config{Times: 3, WaitBase: time.Millisecond})
+
+	calls := 0
+	err := runner.Run(context.TODO(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	assert.NoError(err)
+	assert.Equal(3, calls)
+}
+
+func TestRetryGivesUpAfterTimesExhausted(t *testing.T) {
+	assert := assert.New(t)
+
+	runner := retry.New(retry.Config{Times: 2, WaitBase: time.Millisecond})
+
+	calls := 0
+	err := runner.Run(context.TODO(), func(ctx context.Context) error {
+		calls++
+		return errors.New("boom")
+	})
+
+	assert.ErrorIs(err, goresilienceerrors.ErrRetriesExhausted)
+	assert.Equal(2, calls)
+}
+
+func TestRetryNeverRetriesUserCancellation(t *testing.T) {
+	assert := assert.New(t)
+
+	runner := retry.New(retry.Config{Times: 3, WaitBase: time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	_ = runner.Run(ctx, func(ctx context.Context) error {
+		calls++
+		return ctx.Err()
+	})
+
+	assert.Equal(1, calls, "a user cancelled context should never be retried")
+}
+
+func TestRetryOnCauseGatesRetryingATimeout(t *testing.T) {
+	for _, retryOnCause := range []bool{false, true} {
+		retryOnCause := retryOnCause
+		t.Run("", func(t *testing.T) {
+			assert := assert.New(t)
+
+			runner := goresilience.RunnerChain(
+				retry.NewMiddleware(retry.Config{Times: 3, WaitBase: time.Millisecond, RetryOnCause: retryOnCause}),
+				timeout.NewMiddleware(timeout.Config{Timeout: 5 * time.Millisecond}),
+			)
+
+			// timeout returns as soon as its deadline passes without
+			// waiting for the attempt to return, so a still-running
+			// attempt's goroutine overlaps with the next retry's, calls must
+			// be atomic.
+			var calls int32
+			err := runner.Run(context.TODO(), func(ctx context.Context) error {
+				atomic.AddInt32(&calls, 1)
+				<-ctx.Done()
+				// Give timeout's select a chance to pick the ctx.Done()
+				// branch before this func returns, so the race between the
+				// two is resolved deterministically for the test.
+				time.Sleep(20 * time.Millisecond)
+				return ctx.Err()
+			})
+
+			if retryOnCause {
+				assert.ErrorIs(err, goresilienceerrors.ErrRetriesExhausted)
+				assert.Equal(int32(3), atomic.LoadInt32(&calls), "a timeout should be retried when RetryOnCause is enabled")
+			} else {
+				assert.ErrorIs(err, goresilienceerrors.ErrTimeout)
+				assert.Equal(int32(1), atomic.LoadInt32(&calls), "a timeout shouldn't be retried when RetryOnCause is disabled")
+			}
+		})
+	}
+}
+
+var _ goresilience.Runner = retry.New(retry.Config{})
@@ -53,8 +53,14 @@ func NewMiddleware(cfg Config) goresilience.Middleware {
 		return goresilience.RunnerFunc(func(ctx context.Context, f goresilience.Func) error {
 			metricsRecorder, _ := metrics.RecorderFromContext(ctx)
 
-			// Set a timeout to the command using the context.
-			ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+			// Set a timeout to the command using the context. We set the
+			// cause so any code running inside f that holds onto this exact
+			// ctx can tell our timeout apart from a user cancellation by
+			// calling context.Cause(ctx). Middlewares further up the chain
+			// don't see this derived ctx though, they classify the
+			// errors.ErrTimeout returned below instead, see
+			// goresilience.ClassifyError.
+			ctx, cancel := context.WithTimeoutCause(ctx, cfg.Timeout, errors.ErrTimeout)
 			if cfg.Cancel {
 				defer cancel()
 			}